@@ -5,6 +5,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -352,3 +353,59 @@ func BenchmarkConcurrentSetGetSmall20_100(b *testing.B) {
 func BenchmarkConcurrentSetGetLarge20_100(b *testing.B) {
 	benchmarkConcurrentSetGet(b, largeItem(), 20, 100)
 }
+
+// benchmarkConcurrentGetOrLoad mirrors benchmarkConcurrentSetGet's thundering
+// herd, but every goroutine races on the same missing key via GetOrLoad. The
+// loader blocks on the entered barrier until all count goroutines have
+// started and are about to call GetOrLoad, so the leader cannot finish (and
+// clear the in-flight call) before a descheduled goroutine has even started.
+// It asserts that the loader is invoked exactly once per round despite the
+// concurrent misses.
+func benchmarkConcurrentGetOrLoad(b *testing.B, count int) {
+	mp := runtime.GOMAXPROCS(0)
+	defer runtime.GOMAXPROCS(mp)
+	runtime.GOMAXPROCS(count)
+	cmd, c := newUnixServer(b)
+	c.SetTimeout(time.Duration(-1))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := fmt.Sprintf("getorload-%d", i)
+		var loads int32
+		var entered sync.WaitGroup
+		entered.Add(count)
+		var wg sync.WaitGroup
+		wg.Add(count)
+		for j := 0; j < count; j++ {
+			go func() {
+				defer wg.Done()
+				entered.Done()
+				_, err := c.GetOrLoad(key, func() (*Item, error) {
+					entered.Wait()
+					atomic.AddInt32(&loads, 1)
+					return &Item{Key: key, Value: []byte("v")}, nil
+				})
+				if err != nil {
+					b.Errorf("GetOrLoad: %v", err)
+					return
+				}
+			}()
+		}
+		wg.Wait()
+		if n := atomic.LoadInt32(&loads); n != 1 {
+			b.Fatalf("loader invoked %d times for %d concurrent misses on the same key, want 1", n, count)
+		}
+		c.Delete(key)
+	}
+	b.StopTimer()
+	cmd.Process.Kill()
+	cmd.Wait()
+}
+
+func BenchmarkConcurrentGetOrLoad10(b *testing.B) {
+	benchmarkConcurrentGetOrLoad(b, 10)
+}
+
+func BenchmarkConcurrentGetOrLoad20(b *testing.B) {
+	benchmarkConcurrentGetOrLoad(b, 20)
+}
@@ -0,0 +1,861 @@
+/*
+Copyright 2011 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package memcache provides a client for the memcached cache server.
+package memcache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Similar in spirit to the appengine/memcache client, but speaks the
+// classic memcached text protocol directly over TCP or unix sockets.
+
+var (
+	// ErrCacheMiss means that a Get failed because the item wasn't present.
+	ErrCacheMiss = errors.New("memcache: cache miss")
+
+	// ErrNotStored means that a conditional write operation (i.e. Add) failed
+	// because the condition was not satisfied.
+	ErrNotStored = errors.New("memcache: item not stored")
+
+	// ErrServerError means that a server error occurred.
+	ErrServerError = errors.New("memcache: server error")
+
+	// ErrMalformedKey is returned when an invalid key is used. Keys must be at
+	// maximum 250 bytes long and not contain whitespace or control characters.
+	ErrMalformedKey = errors.New("memcache: malformed key is too long or contains invalid characters")
+
+	// ErrNoServers is returned when no servers are configured or available.
+	ErrNoServers = errors.New("memcache: no servers configured or available")
+
+	// ErrBadIncrDec means that incr/decr was called on a non-numeric value.
+	ErrBadIncrDec = errors.New("memcache: incr or decr called on non-numeric value")
+)
+
+const (
+	// DefaultTimeout is the default socket read/write timeout.
+	DefaultTimeout = 100 * time.Millisecond
+
+	// DefaultMaxIdleConns is the default number of idle connections kept
+	// per address.
+	DefaultMaxIdleConns = 2
+
+	maxKeyLength = 250
+)
+
+// Item is an item to be cached in memcached.
+type Item struct {
+	// Key is the Item's key (250 bytes maximum).
+	Key string
+
+	// Value is the Item's value.
+	Value []byte
+
+	// Flags are server-opaque flags whose semantics are entirely
+	// up to the app.
+	Flags uint32
+
+	// Expiration is the cache expiration time, in seconds: either a relative
+	// time from now (up to 1 month), or an absolute Unix epoch time.
+	// Zero means the Item has no expiration time.
+	Expiration int32
+}
+
+// ConnectTimeoutError is returned when a connection to a memcached server
+// could not be established within the configured timeout.
+type ConnectTimeoutError struct {
+	Addr string
+}
+
+func (cte *ConnectTimeoutError) Error() string {
+	return "memcache: connect timeout to " + cte.Addr
+}
+
+// resumableError returns true if err is only a protocol-level cache error
+// and not a connection-level error.
+func resumableError(err error) bool {
+	switch err {
+	case ErrCacheMiss, ErrCASConflict, ErrNotStored, ErrMalformedKey:
+		return true
+	}
+	return false
+}
+
+// ErrCASConflict means that a CompareAndSwap call failed due to the
+// cached value being modified between the Get and the CompareAndSwap.
+var ErrCASConflict = errors.New("memcache: compare-and-swap conflict")
+
+func legalKey(key string) bool {
+	if len(key) == 0 || len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveAddr splits a server string into the network and address that
+// net.Dial expects. Addresses containing a "/" are treated as unix socket
+// paths; everything else is dialed over tcp.
+func resolveAddr(server string) (network, address string) {
+	if strings.Contains(server, "/") {
+		return "unix", server
+	}
+	return "tcp", server
+}
+
+// conn is a connection to a memcached server, wrapped with a buffered
+// reader/writer and tracked so it can be returned to the free list.
+type conn struct {
+	nc   net.Conn
+	rw   *bufio.ReadWriter
+	addr string
+	c    *Client
+}
+
+func (cn *conn) extendDeadline() {
+	t := cn.c.netTimeout()
+	if t < 0 {
+		return
+	}
+	cn.nc.SetDeadline(time.Now().Add(t))
+}
+
+// release gives cn back to the connection pool it came from.
+func (cn *conn) release() {
+	cn.c.putFreeConn(cn.addr, cn)
+}
+
+func (cn *conn) condRelease(err *error) {
+	if *err == nil || resumableError(*err) {
+		cn.release()
+	} else {
+		cn.nc.Close()
+	}
+}
+
+// serverAddr is the dial information for one backing memcached server.
+type serverAddr struct {
+	network string
+	address string
+}
+
+// Client is a memcached client. It is safe for concurrent use by multiple
+// goroutines.
+//
+// A Client created by New talks to a single server. A Client created by
+// NewSharded spreads keys across several servers by consistent hashing; solo
+// is unused in that mode and ring routes each key to a server name instead.
+type Client struct {
+	solo    string
+	servers map[string]serverAddr
+	ring    *Map
+
+	mu           sync.Mutex
+	timeout      time.Duration
+	maxIdleConns int
+	freeconn     map[string][]*conn
+
+	flightsMu sync.Mutex
+	flights   map[string]*call
+}
+
+// New returns a memcache client using the given memcached server address,
+// either "host:port" for TCP or a filesystem path for a unix socket.
+func New(server string) (*Client, error) {
+	if server == "" {
+		return nil, ErrNoServers
+	}
+	network, address := resolveAddr(server)
+	return &Client{
+		solo:         server,
+		servers:      map[string]serverAddr{server: {network, address}},
+		maxIdleConns: DefaultMaxIdleConns,
+		freeconn:     make(map[string][]*conn),
+	}, nil
+}
+
+// NewSharded returns a memcache client that spreads keys across the given
+// servers using consistent hashing, so that adding or removing a server only
+// reshuffles a small fraction of keys. Each server address may be a
+// "host:port" for TCP or a filesystem path for a unix socket, exactly as
+// accepted by New.
+func NewSharded(servers []string, opts ...Option) (*Client, error) {
+	if len(servers) == 0 {
+		return nil, ErrNoServers
+	}
+	replicas := defaultReplicas
+	var hash Hash
+	for _, opt := range opts {
+		opt(&replicas, &hash)
+	}
+	c := &Client{
+		servers:      make(map[string]serverAddr, len(servers)),
+		ring:         NewMap(replicas, hash),
+		maxIdleConns: DefaultMaxIdleConns,
+		freeconn:     make(map[string][]*conn),
+	}
+	for _, server := range servers {
+		network, address := resolveAddr(server)
+		c.servers[server] = serverAddr{network, address}
+	}
+	c.ring.Add(servers...)
+	return c, nil
+}
+
+// Option configures a Client constructed by NewSharded.
+type Option func(replicas *int, hash *Hash)
+
+// WithReplicas sets the number of virtual nodes the consistent hash ring
+// places per server. More replicas smooth the key distribution at the cost
+// of a larger ring; the default is defaultReplicas.
+func WithReplicas(replicas int) Option {
+	return func(r *int, _ *Hash) { *r = replicas }
+}
+
+// WithHash overrides the hash function used to place servers and keys on the
+// consistent hash ring. The default is crc32.ChecksumIEEE.
+func WithHash(hash Hash) Option {
+	return func(_ *int, h *Hash) { *h = hash }
+}
+
+// pickServer returns the name of the server that owns key, as found in
+// c.servers.
+func (c *Client) pickServer(key string) (string, error) {
+	if c.ring == nil {
+		if c.solo == "" {
+			return "", ErrNoServers
+		}
+		return c.solo, nil
+	}
+	name := c.ring.Get(key)
+	if name == "" {
+		return "", ErrNoServers
+	}
+	return name, nil
+}
+
+// SetTimeout specifies the socket read/write timeout. If zero, DefaultTimeout
+// is used; if negative, connections never time out.
+func (c *Client) SetTimeout(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeout = d
+}
+
+func (c *Client) netTimeout() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.timeout != 0 {
+		return c.timeout
+	}
+	return DefaultTimeout
+}
+
+// maxIdleConnsLocked returns the configured idle connection limit. It must
+// be called with c.mu already held.
+func (c *Client) maxIdleConnsLocked() int {
+	if c.maxIdleConns > 0 {
+		return c.maxIdleConns
+	}
+	return DefaultMaxIdleConns
+}
+
+func (c *Client) dial(addr serverAddr) (net.Conn, error) {
+	type dialResult struct {
+		nc  net.Conn
+		err error
+	}
+	ch := make(chan dialResult, 1)
+	go func() {
+		nc, err := net.Dial(addr.network, addr.address)
+		ch <- dialResult{nc, err}
+	}()
+	t := c.netTimeout()
+	if t < 0 {
+		res := <-ch
+		return res.nc, res.err
+	}
+	select {
+	case res := <-ch:
+		return res.nc, res.err
+	case <-time.After(t):
+		return nil, &ConnectTimeoutError{addr.address}
+	}
+}
+
+func (c *Client) getFreeConn(addr string) (cn *conn, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	freelist := c.freeconn[addr]
+	if len(freelist) == 0 {
+		return nil, false
+	}
+	cn = freelist[len(freelist)-1]
+	c.freeconn[addr] = freelist[:len(freelist)-1]
+	return cn, true
+}
+
+func (c *Client) putFreeConn(addr string, cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.freeconn == nil {
+		c.freeconn = make(map[string][]*conn)
+	}
+	freelist := c.freeconn[addr]
+	if len(freelist) >= c.maxIdleConnsLocked() {
+		cn.nc.Close()
+		return
+	}
+	c.freeconn[addr] = append(freelist, cn)
+}
+
+// getConn returns a connection to the named server, reusing an idle one from
+// the free list when available.
+func (c *Client) getConn(name string) (*conn, error) {
+	addr, ok := c.servers[name]
+	if !ok {
+		return nil, ErrNoServers
+	}
+	if cn, ok := c.getFreeConn(name); ok {
+		cn.extendDeadline()
+		return cn, nil
+	}
+	nc, err := c.dial(addr)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{
+		nc:   nc,
+		addr: name,
+		rw:   bufio.NewReadWriter(bufio.NewReader(nc), bufio.NewWriter(nc)),
+		c:    c,
+	}
+	cn.extendDeadline()
+	return cn, nil
+}
+
+// withConn routes key to its server and runs fn against a connection to it,
+// closing the connection on any unrecoverable error and otherwise returning
+// it to the free list.
+func (c *Client) withConn(key string, fn func(*conn) error) error {
+	name, err := c.pickServer(key)
+	if err != nil {
+		return err
+	}
+	return c.withServerConn(name, fn)
+}
+
+// withServerConn runs fn against a connection to the named server directly,
+// bypassing key routing. Used for operations, like Flush, that address a
+// specific server rather than a key.
+func (c *Client) withServerConn(name string, fn func(*conn) error) error {
+	cn, err := c.getConn(name)
+	if err != nil {
+		return err
+	}
+	err = fn(cn)
+	cn.condRelease(&err)
+	return err
+}
+
+// fanOut groups keys by the server that owns them and runs fn once per
+// server, concurrently, returning the first error encountered (if any).
+func (c *Client) fanOut(keys []string, fn func(cn *conn, shardKeys []string) error) error {
+	byServer := make(map[string][]string)
+	for _, key := range keys {
+		name, err := c.pickServer(key)
+		if err != nil {
+			return err
+		}
+		byServer[name] = append(byServer[name], key)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(byServer))
+	for name, shardKeys := range byServer {
+		name, shardKeys := name, shardKeys
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cn, err := c.getConn(name)
+			if err != nil {
+				errs <- err
+				return
+			}
+			err = fn(cn, shardKeys)
+			cn.condRelease(&err)
+			if err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		return err
+	}
+	return nil
+}
+
+// fanOutItems is like fanOut but groups Items by the server that owns their
+// key, for the batch storage commands.
+func (c *Client) fanOutItems(items []*Item, fn func(cn *conn, shardItems []*Item) error) error {
+	byServer := make(map[string][]*Item)
+	for _, item := range items {
+		name, err := c.pickServer(item.Key)
+		if err != nil {
+			return err
+		}
+		byServer[name] = append(byServer[name], item)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(byServer))
+	for name, shardItems := range byServer {
+		name, shardItems := name, shardItems
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cn, err := c.getConn(name)
+			if err != nil {
+				errs <- err
+				return
+			}
+			err = fn(cn, shardItems)
+			cn.condRelease(&err)
+			if err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		return err
+	}
+	return nil
+}
+
+func scanLine(rw *bufio.ReadWriter) (string, error) {
+	line, err := rw.ReadSlice('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(line), "\r\n"), nil
+}
+
+func parseErrorLine(line string) error {
+	switch {
+	case line == "ERROR":
+		return ErrServerError
+	case strings.HasPrefix(line, "CLIENT_ERROR "):
+		return errors.New("memcache: client error: " + line[len("CLIENT_ERROR "):])
+	case strings.HasPrefix(line, "SERVER_ERROR "):
+		return errors.New("memcache: server error: " + line[len("SERVER_ERROR "):])
+	}
+	return nil
+}
+
+// readGetResponse reads VALUE lines and their payloads until END, invoking cb
+// for each item found.
+func readGetResponse(rw *bufio.ReadWriter, cb func(*Item)) error {
+	for {
+		line, err := scanLine(rw)
+		if err != nil {
+			return err
+		}
+		if line == "END" {
+			return nil
+		}
+		if err := parseErrorLine(line); err != nil {
+			return err
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 4 || fields[0] != "VALUE" {
+			return fmt.Errorf("memcache: unexpected line in get response: %q", line)
+		}
+		flags64, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return err
+		}
+		size, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			return err
+		}
+		it := &Item{Key: fields[1], Flags: uint32(flags64)}
+		it.Value = make([]byte, size+2)
+		if _, err := io.ReadFull(rw, it.Value); err != nil {
+			return err
+		}
+		it.Value = it.Value[:size]
+		cb(it)
+	}
+}
+
+// Get gets the item for the given key. ErrCacheMiss is returned if the item
+// isn't present.
+func (c *Client) Get(key string) (item *Item, err error) {
+	if !legalKey(key) {
+		return nil, ErrMalformedKey
+	}
+	err = c.withConn(key, func(cn *conn) error {
+		if _, err := fmt.Fprintf(cn.rw, "get %s\r\n", key); err != nil {
+			return err
+		}
+		if err := cn.rw.Flush(); err != nil {
+			return err
+		}
+		found := false
+		if err := readGetResponse(cn.rw, func(it *Item) {
+			item = it
+			found = true
+		}); err != nil {
+			return err
+		}
+		if !found {
+			return ErrCacheMiss
+		}
+		return nil
+	})
+	return item, err
+}
+
+// GetMulti is a batch version of Get. The returned map from keys to items may
+// have fewer elements than the input slice, due to memcache cache misses.
+// Each key must be at most 250 bytes in length. Keys are fanned out to
+// whichever servers own them and queried concurrently.
+func (c *Client) GetMulti(keys []string) (map[string]*Item, error) {
+	for _, key := range keys {
+		if !legalKey(key) {
+			return nil, ErrMalformedKey
+		}
+	}
+	m := make(map[string]*Item)
+	if len(keys) == 0 {
+		return m, nil
+	}
+	var mu sync.Mutex
+	err := c.fanOut(keys, func(cn *conn, shardKeys []string) error {
+		if _, err := fmt.Fprintf(cn.rw, "get %s\r\n", strings.Join(shardKeys, " ")); err != nil {
+			return err
+		}
+		if err := cn.rw.Flush(); err != nil {
+			return err
+		}
+		return readGetResponse(cn.rw, func(it *Item) {
+			mu.Lock()
+			m[it.Key] = it
+			mu.Unlock()
+		})
+	})
+	return m, err
+}
+
+// writeStorageCommand writes a storage command and its payload, then checks
+// the server's reply unless quiet is true (in which case no reply is sent).
+func writeStorageCommand(rw *bufio.ReadWriter, verb string, item *Item, quiet bool) error {
+	if !legalKey(item.Key) {
+		return ErrMalformedKey
+	}
+	noreply := ""
+	if quiet {
+		noreply = " noreply"
+	}
+	if _, err := fmt.Fprintf(rw, "%s %s %d %d %d%s\r\n", verb, item.Key, item.Flags, item.Expiration, len(item.Value), noreply); err != nil {
+		return err
+	}
+	if _, err := rw.Write(item.Value); err != nil {
+		return err
+	}
+	if _, err := rw.Write([]byte("\r\n")); err != nil {
+		return err
+	}
+	if err := rw.Flush(); err != nil {
+		return err
+	}
+	if quiet {
+		return nil
+	}
+	line, err := scanLine(rw)
+	if err != nil {
+		return err
+	}
+	switch line {
+	case "STORED":
+		return nil
+	case "NOT_STORED":
+		return ErrNotStored
+	case "EXISTS":
+		return ErrCASConflict
+	}
+	if e := parseErrorLine(line); e != nil {
+		return e
+	}
+	return fmt.Errorf("memcache: unexpected response line: %q", line)
+}
+
+func (c *Client) store(verb string, item *Item, quiet bool) error {
+	if !legalKey(item.Key) {
+		return ErrMalformedKey
+	}
+	return c.withConn(item.Key, func(cn *conn) error {
+		return writeStorageCommand(cn.rw, verb, item, quiet)
+	})
+}
+
+// Set writes the given item, unconditionally.
+func (c *Client) Set(item *Item) error {
+	return c.store("set", item, false)
+}
+
+// SetQuietly is like Set but does not wait for or check the server's reply.
+func (c *Client) SetQuietly(item *Item) error {
+	return c.store("set", item, true)
+}
+
+// Add writes the given item, if no value already exists for its key.
+// ErrNotStored is returned if that condition is not met.
+func (c *Client) Add(item *Item) error {
+	return c.store("add", item, false)
+}
+
+// Replace writes the given item, but only if the server already holds data
+// for this key.
+func (c *Client) Replace(item *Item) error {
+	return c.store("replace", item, false)
+}
+
+func (c *Client) storeMulti(verb string, items []*Item, quiet bool) error {
+	for _, item := range items {
+		if !legalKey(item.Key) {
+			return ErrMalformedKey
+		}
+	}
+	return c.fanOutItems(items, func(cn *conn, shardItems []*Item) error {
+		for _, item := range shardItems {
+			if err := writeStorageCommand(cn.rw, verb, item, quiet); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetMulti is a batch version of Set.
+func (c *Client) SetMulti(items []*Item) error {
+	return c.storeMulti("set", items, false)
+}
+
+// SetMultiQuietly is a batch version of SetQuietly.
+func (c *Client) SetMultiQuietly(items []*Item) error {
+	return c.storeMulti("set", items, true)
+}
+
+func (c *Client) delete(key string, quiet bool) error {
+	if !legalKey(key) {
+		return ErrMalformedKey
+	}
+	return c.withConn(key, func(cn *conn) error {
+		noreply := ""
+		if quiet {
+			noreply = " noreply"
+		}
+		if _, err := fmt.Fprintf(cn.rw, "delete %s%s\r\n", key, noreply); err != nil {
+			return err
+		}
+		if err := cn.rw.Flush(); err != nil {
+			return err
+		}
+		if quiet {
+			return nil
+		}
+		line, err := scanLine(cn.rw)
+		if err != nil {
+			return err
+		}
+		switch line {
+		case "DELETED":
+			return nil
+		case "NOT_FOUND":
+			return ErrCacheMiss
+		}
+		if e := parseErrorLine(line); e != nil {
+			return e
+		}
+		return fmt.Errorf("memcache: unexpected response line: %q", line)
+	})
+}
+
+// Delete deletes the item with the provided key.
+func (c *Client) Delete(key string) error {
+	return c.delete(key, false)
+}
+
+// DeleteQuietly is like Delete but does not wait for or check the server's
+// reply.
+func (c *Client) DeleteQuietly(key string) error {
+	return c.delete(key, true)
+}
+
+func (c *Client) deleteMulti(keys []string, quiet bool) error {
+	for _, key := range keys {
+		if !legalKey(key) {
+			return ErrMalformedKey
+		}
+	}
+	return c.fanOut(keys, func(cn *conn, shardKeys []string) error {
+		for _, key := range shardKeys {
+			noreply := ""
+			if quiet {
+				noreply = " noreply"
+			}
+			if _, err := fmt.Fprintf(cn.rw, "delete %s%s\r\n", key, noreply); err != nil {
+				return err
+			}
+			if err := cn.rw.Flush(); err != nil {
+				return err
+			}
+			if quiet {
+				continue
+			}
+			line, err := scanLine(cn.rw)
+			if err != nil {
+				return err
+			}
+			switch line {
+			case "DELETED", "NOT_FOUND":
+			default:
+				if e := parseErrorLine(line); e != nil {
+					return e
+				}
+				return fmt.Errorf("memcache: unexpected response line: %q", line)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteMulti is a batch version of Delete.
+func (c *Client) DeleteMulti(keys []string) error {
+	return c.deleteMulti(keys, false)
+}
+
+// DeleteMultiQuietly is a batch version of DeleteQuietly.
+func (c *Client) DeleteMultiQuietly(keys []string) error {
+	return c.deleteMulti(keys, true)
+}
+
+// Flush empties the cache. delay is the number of seconds to wait before
+// flushing, or 0 to flush immediately. In sharded mode every server in the
+// cluster is flushed.
+func (c *Client) Flush(delay int32) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, len(c.servers))
+	for name := range c.servers {
+		name := name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- c.withServerConn(name, func(cn *conn) error {
+				if _, err := fmt.Fprintf(cn.rw, "flush_all %d\r\n", delay); err != nil {
+					return err
+				}
+				if err := cn.rw.Flush(); err != nil {
+					return err
+				}
+				line, err := scanLine(cn.rw)
+				if err != nil {
+					return err
+				}
+				if line != "OK" {
+					if e := parseErrorLine(line); e != nil {
+						return e
+					}
+					return fmt.Errorf("memcache: unexpected response line: %q", line)
+				}
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) incrDecr(verb, key string, delta uint64) (uint64, error) {
+	if !legalKey(key) {
+		return 0, ErrMalformedKey
+	}
+	var val uint64
+	err := c.withConn(key, func(cn *conn) error {
+		if _, err := fmt.Fprintf(cn.rw, "%s %s %d\r\n", verb, key, delta); err != nil {
+			return err
+		}
+		if err := cn.rw.Flush(); err != nil {
+			return err
+		}
+		line, err := scanLine(cn.rw)
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "NOT_FOUND":
+			return ErrCacheMiss
+		case strings.Contains(line, "cannot increment or decrement non-numeric value"):
+			return ErrBadIncrDec
+		}
+		if e := parseErrorLine(line); e != nil {
+			return e
+		}
+		val, err = strconv.ParseUint(line, 10, 64)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	return val, err
+}
+
+// Increment atomically increments key by delta. The return value is the new
+// value after incrementing or an error. If the value didn't exist in
+// memcached the error is ErrCacheMiss. The value in memcached must be an
+// decimal number, or an error will be returned.
+func (c *Client) Increment(key string, delta uint64) (uint64, error) {
+	return c.incrDecr("incr", key, delta)
+}
+
+// Decrement atomically decrements key by delta. The return value is the new
+// value after decrementing or an error. Decrementing a value below zero
+// clamps it to zero. See Increment for error conditions.
+func (c *Client) Decrement(key string, delta uint64) (uint64, error) {
+	return c.incrDecr("decr", key, delta)
+}
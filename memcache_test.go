@@ -23,6 +23,8 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -89,6 +91,88 @@ func TestUnixSocket(t *testing.T) {
 	testWithClient(t, c)
 }
 
+// newUnixServerCluster starts n memcached instances, each on its own unix
+// socket, mirroring newUnixServer but for a small sharded cluster.
+func newUnixServerCluster(tb testing.TB, n int) ([]*exec.Cmd, []string) {
+	cmds := make([]*exec.Cmd, 0, n)
+	servers := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		sock := fmt.Sprintf("/tmp/test-gomemcache-shard%d-%d.sock", i, os.Getpid())
+		os.Remove(sock)
+		cmd := exec.Command("memcached", "-s", sock)
+		if err := cmd.Start(); err != nil {
+			for _, c := range cmds {
+				c.Process.Kill()
+				c.Wait()
+			}
+			tb.Skip("skipping test; couldn't find memcached")
+			return nil, nil
+		}
+		for j := 0; j < 10; j++ {
+			if _, err := os.Stat(sock); err == nil {
+				break
+			}
+			time.Sleep(time.Duration(25*j) * time.Millisecond)
+		}
+		cmds = append(cmds, cmd)
+		servers = append(servers, sock)
+	}
+	return cmds, servers
+}
+
+// TestSharded runs the full client test suite against a NewSharded Client
+// backed by a small cluster of memcached unix sockets.
+func TestSharded(t *testing.T) {
+	cmds, servers := newUnixServerCluster(t, 3)
+	defer func() {
+		for _, cmd := range cmds {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+	}()
+	c, err := NewSharded(servers)
+	if err != nil {
+		t.Fatalf("NewSharded: %v", err)
+	}
+	testWithClient(t, c)
+}
+
+// TestShardedRoutesAcrossServers checks that keys set through a sharded
+// Client land on more than one backing server, i.e. that routing isn't
+// accidentally collapsing everything onto a single shard.
+func TestShardedRoutesAcrossServers(t *testing.T) {
+	cmds, servers := newUnixServerCluster(t, 3)
+	defer func() {
+		for _, cmd := range cmds {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+	}()
+	c, err := NewSharded(servers)
+	if err != nil {
+		t.Fatalf("NewSharded: %v", err)
+	}
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("shard-key-%d", i)
+		if err := c.Set(&Item{Key: key, Value: []byte("v")}); err != nil {
+			t.Fatalf("Set(%s): %v", key, err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("shard-key-%d", i)
+		name, err := c.pickServer(key)
+		if err != nil {
+			t.Fatalf("pickServer(%s): %v", key, err)
+		}
+		seen[name] = true
+	}
+	if len(seen) < 2 {
+		t.Fatalf("keys only routed to %d distinct server(s), want spread across the cluster", len(seen))
+	}
+}
+
 func testWithClient(t *testing.T, c *Client) {
 	checkErr := func(err error, format string, args ...interface{}) {
 		if err != nil {
@@ -351,3 +435,112 @@ func testWithClient(t *testing.T, c *Client) {
 		t.Fatalf("post-flush: want ErrCacheMiss, got %v", err)
 	}
 }
+
+// TestGetOrLoad checks that GetOrLoad falls back to the loader on a miss,
+// populates the cache with its result, and skips the loader entirely on a
+// subsequent hit.
+func TestGetOrLoad(t *testing.T) {
+	cmd, c := newUnixServer(t)
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	var loads int32
+	loader := func() (*Item, error) {
+		atomic.AddInt32(&loads, 1)
+		return &Item{Key: "loadme", Value: []byte("loaded")}, nil
+	}
+
+	it, err := c.GetOrLoad("loadme", loader)
+	if err != nil {
+		t.Fatalf("GetOrLoad (miss): %v", err)
+	}
+	if string(it.Value) != "loaded" {
+		t.Errorf("GetOrLoad (miss) value = %q, want loaded", it.Value)
+	}
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatalf("loader invoked %d times on first call, want 1", n)
+	}
+
+	it, err = c.GetOrLoad("loadme", loader)
+	if err != nil {
+		t.Fatalf("GetOrLoad (hit): %v", err)
+	}
+	if string(it.Value) != "loaded" {
+		t.Errorf("GetOrLoad (hit) value = %q, want loaded", it.Value)
+	}
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatalf("loader invoked %d times after a cache hit, want 1 (no extra call)", n)
+	}
+}
+
+// TestGetOrLoadCoalescesConcurrentMisses checks that many goroutines racing
+// on the same missing key cause exactly one loader invocation.
+func TestGetOrLoadCoalescesConcurrentMisses(t *testing.T) {
+	cmd, c := newUnixServer(t)
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	var loads int32
+	const goroutines = 50
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			_, err := c.GetOrLoad("coalesce", func() (*Item, error) {
+				atomic.AddInt32(&loads, 1)
+				time.Sleep(10 * time.Millisecond)
+				return &Item{Key: "coalesce", Value: []byte("v")}, nil
+			})
+			if err != nil {
+				t.Errorf("GetOrLoad: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatalf("loader invoked %d times for %d concurrent misses, want 1", n, goroutines)
+	}
+}
+
+// TestGetMultiOrLoad checks that only the keys missing from the cache are
+// passed to the loader, and that the results are merged into the returned
+// map alongside the cache hits.
+func TestGetMultiOrLoad(t *testing.T) {
+	cmd, c := newUnixServer(t)
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	if err := c.Set(&Item{Key: "present", Value: []byte("cached")}); err != nil {
+		t.Fatalf("Set(present): %v", err)
+	}
+
+	var loaded []string
+	var mu sync.Mutex
+	loader := func(key string) (*Item, error) {
+		mu.Lock()
+		loaded = append(loaded, key)
+		mu.Unlock()
+		return &Item{Key: key, Value: []byte("loaded-" + key)}, nil
+	}
+
+	items, err := c.GetMultiOrLoad([]string{"present", "missing1", "missing2"}, loader)
+	if err != nil {
+		t.Fatalf("GetMultiOrLoad: %v", err)
+	}
+	if g, e := len(items), 3; g != e {
+		t.Fatalf("GetMultiOrLoad: got len(map) = %d, want %d", g, e)
+	}
+	if string(items["present"].Value) != "cached" {
+		t.Errorf("GetMultiOrLoad: present = %q, want cached", items["present"].Value)
+	}
+	if string(items["missing1"].Value) != "loaded-missing1" {
+		t.Errorf("GetMultiOrLoad: missing1 = %q, want loaded-missing1", items["missing1"].Value)
+	}
+	if string(items["missing2"].Value) != "loaded-missing2" {
+		t.Errorf("GetMultiOrLoad: missing2 = %q, want loaded-missing2", items["missing2"].Value)
+	}
+	if g, e := len(loaded), 2; g != e {
+		t.Fatalf("loader invoked %d times, want %d (only for missing keys)", g, e)
+	}
+}
@@ -0,0 +1,71 @@
+package memcache
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// defaultReplicas is the number of virtual nodes NewSharded places on the
+// ring per server when no WithReplicas option is given.
+const defaultReplicas = 50
+
+// Hash maps a byte slice to a ring position.
+type Hash func(data []byte) uint32
+
+// Map is a consistent hash ring mapping keys to server names. It gives
+// minimal key reshuffling when servers are added or removed: on average only
+// a 1/N fraction of keys move to a different server.
+type Map struct {
+	hash     Hash
+	replicas int
+	keys     []int // sorted hash ring positions
+	hashMap  map[int]string
+}
+
+// NewMap returns an empty ring with the given number of virtual nodes per
+// server. If hash is nil, crc32.ChecksumIEEE is used.
+func NewMap(replicas int, hash Hash) *Map {
+	if replicas <= 0 {
+		replicas = defaultReplicas
+	}
+	m := &Map{
+		replicas: replicas,
+		hash:     hash,
+		hashMap:  make(map[int]string),
+	}
+	if m.hash == nil {
+		m.hash = crc32.ChecksumIEEE
+	}
+	return m
+}
+
+// IsEmpty returns true if the ring has no servers.
+func (m *Map) IsEmpty() bool {
+	return len(m.keys) == 0
+}
+
+// Add inserts servers into the ring, each as m.replicas virtual nodes.
+func (m *Map) Add(servers ...string) {
+	for _, server := range servers {
+		for i := 0; i < m.replicas; i++ {
+			hash := int(m.hash([]byte(strconv.Itoa(i) + server)))
+			m.keys = append(m.keys, hash)
+			m.hashMap[hash] = server
+		}
+	}
+	sort.Ints(m.keys)
+}
+
+// Get returns the server responsible for key, or "" if the ring is empty.
+func (m *Map) Get(key string) string {
+	if m.IsEmpty() {
+		return ""
+	}
+	hash := int(m.hash([]byte(key)))
+	idx := sort.Search(len(m.keys), func(i int) bool { return m.keys[i] >= hash })
+	if idx == len(m.keys) {
+		idx = 0
+	}
+	return m.hashMap[m.keys[idx]]
+}
@@ -0,0 +1,58 @@
+package memcache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMapGetIsStable(t *testing.T) {
+	m := NewMap(50, nil)
+	m.Add("10.0.0.1:11211", "10.0.0.2:11211", "10.0.0.3:11211")
+
+	want := m.Get("foo")
+	for i := 0; i < 100; i++ {
+		if got := m.Get("foo"); got != want {
+			t.Fatalf("Get(foo) = %q on call %d, want %q", got, i, want)
+		}
+	}
+}
+
+func TestMapGetEmpty(t *testing.T) {
+	m := NewMap(50, nil)
+	if got := m.Get("foo"); got != "" {
+		t.Errorf("Get on empty Map = %q, want \"\"", got)
+	}
+}
+
+func TestMapDistributionStableOnAdd(t *testing.T) {
+	const numKeys = 10000
+	keys := make([]string, numKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+
+	before := NewMap(50, nil)
+	before.Add("10.0.0.1:11211", "10.0.0.2:11211", "10.0.0.3:11211")
+
+	owner := make(map[string]string, numKeys)
+	for _, k := range keys {
+		owner[k] = before.Get(k)
+	}
+
+	after := NewMap(50, nil)
+	after.Add("10.0.0.1:11211", "10.0.0.2:11211", "10.0.0.3:11211", "10.0.0.4:11211")
+
+	moved := 0
+	for _, k := range keys {
+		if after.Get(k) != owner[k] {
+			moved++
+		}
+	}
+
+	// Growing a 3-server ring to 4 servers should move roughly 1/4 of the
+	// keys. Consistent hashing isn't perfectly even with a finite replica
+	// count, so allow a generous margin around that expectation.
+	if frac := float64(moved) / float64(numKeys); frac < 0.15 || frac > 0.40 {
+		t.Fatalf("adding a server moved %d/%d keys (%.1f%%), want roughly 25%%", moved, numKeys, frac*100)
+	}
+}
@@ -0,0 +1,121 @@
+package memcache
+
+import (
+	"fmt"
+	"sync"
+)
+
+// call tracks a loader invocation in flight for a single key, so that
+// concurrent misses for the same key can wait on its result instead of each
+// invoking the loader themselves.
+type call struct {
+	wg  sync.WaitGroup
+	val *Item
+	err error
+}
+
+// GetOrLoad returns the cached item for key, falling back to loader on a
+// cache miss. Concurrent callers that miss on the same key are coalesced:
+// only one of them actually invokes loader, and the rest wait for its
+// result. On a successful load the item is written back to the cache before
+// being returned.
+func (c *Client) GetOrLoad(key string, loader func() (*Item, error)) (*Item, error) {
+	item, err := c.Get(key)
+	if err != ErrCacheMiss {
+		return item, err
+	}
+
+	c.flightsMu.Lock()
+	if cl, ok := c.flights[key]; ok {
+		c.flightsMu.Unlock()
+		cl.wg.Wait()
+		return cl.val, cl.err
+	}
+	cl := new(call)
+	cl.wg.Add(1)
+	if c.flights == nil {
+		c.flights = make(map[string]*call)
+	}
+	c.flights[key] = cl
+	c.flightsMu.Unlock()
+
+	cl.val, cl.err = c.runLoader(key, loader)
+
+	c.flightsMu.Lock()
+	delete(c.flights, key)
+	c.flightsMu.Unlock()
+	cl.wg.Done()
+
+	return cl.val, cl.err
+}
+
+// runLoader invokes loader, recovering from any panic so that waiters are
+// always released, and stores the loaded item in the cache on success.
+func (c *Client) runLoader(key string, loader func() (*Item, error)) (item *Item, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			item, err = nil, fmt.Errorf("memcache: loader for %q panicked: %v", key, r)
+		}
+	}()
+	item, err = loader()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Set(item); err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+// GetMultiOrLoad is the batch version of GetOrLoad. loader is invoked once
+// per key missing from the cache; concurrent misses for the same key are
+// still coalesced into a single call, exactly as in GetOrLoad.
+func (c *Client) GetMultiOrLoad(keys []string, loader func(key string) (*Item, error)) (map[string]*Item, error) {
+	items, err := c.GetMulti(keys)
+	if err != nil {
+		return items, err
+	}
+
+	var missing []string
+	for _, key := range keys {
+		if _, ok := items[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return items, nil
+	}
+
+	type loaded struct {
+		key  string
+		item *Item
+		err  error
+	}
+	results := make(chan loaded, len(missing))
+	var wg sync.WaitGroup
+	wg.Add(len(missing))
+	for _, key := range missing {
+		key := key
+		go func() {
+			defer wg.Done()
+			item, err := c.GetOrLoad(key, func() (*Item, error) {
+				return loader(key)
+			})
+			results <- loaded{key, item, err}
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	var firstErr error
+	for r := range results {
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		items[r.key] = r.item
+	}
+	return items, firstErr
+}